@@ -0,0 +1,78 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Difference describes a single field where desired and observed disagree.
+type Difference struct {
+	// Path is the dot-separated field path the mismatch was found at.
+	Path string
+	// Desired is the value desired declares at Path.
+	Desired interface{}
+	// Observed is the value observed carries at Path, or nil if it's absent.
+	Observed interface{}
+}
+
+// Compare transforms a copy of desired using the default transformer
+// registry (see RegisterTransformer) to its server-side representation, then
+// returns a Difference for every field desired declares whose value doesn't
+// match observed. Fields observed carries that desired doesn't mention (e.g.
+// server-assigned metadata, status) are not considered drift.
+//
+// Callers that need cluster-specific transformers on top of the default
+// registry (e.g. MultiClusterCompare) should transform their own copy of
+// desired first; running it back through here afterward is harmless, since
+// every registered transformer is a no-op on a field it has already handled.
+func Compare(desired, observed *unstructured.Unstructured) ([]Difference, error) {
+	transformed := desired.DeepCopy()
+	if err := TransformObjectToServerSideRepresentation(transformed); err != nil {
+		return nil, fmt.Errorf("failed to transform desired object: %w", err)
+	}
+
+	var differences []Difference
+	compareValues("", transformed.Object, observed.Object, &differences)
+	return differences, nil
+}
+
+// compareValues recursively compares desired against observed, appending a
+// Difference to differences for every leaf value or map key present in
+// desired that observed lacks or disagrees with.
+func compareValues(path string, desired, observed interface{}, differences *[]Difference) {
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if desiredIsMap {
+		observedMap, _ := observed.(map[string]interface{})
+		for k, desiredVal := range desiredMap {
+			compareValues(joinPath(path, k), desiredVal, observedMap[k], differences)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(desired, observed) {
+		*differences = append(*differences, Difference{Path: path, Desired: desired, Observed: observed})
+	}
+}
+
+// joinPath appends field to the dot-separated path prefix.
+func joinPath(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fakeKeyProvider decrypts any value carrying a given prefix by stripping it.
+type fakeKeyProvider struct {
+	prefix     string
+	decryptErr error
+}
+
+func (p *fakeKeyProvider) CanDecrypt(value string) bool {
+	return len(value) >= len(p.prefix) && value[:len(p.prefix)] == p.prefix
+}
+
+func (p *fakeKeyProvider) Decrypt(value string) (string, error) {
+	if p.decryptErr != nil {
+		return "", p.decryptErr
+	}
+	return value[len(p.prefix):], nil
+}
+
+func TestEncryptedSecretTransformer_CanTransform(t *testing.T) {
+	transformer := NewEncryptedSecretTransformer()
+
+	tests := []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		expected bool
+	}{
+		{
+			name: "secret with sops envelope",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Secret",
+					"stringData": map[string]interface{}{
+						"password": sopsEnvelopePrefix + "abc==,iv:xyz==,tag:123==,type:str]",
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "secret with annotation but plain values",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Secret",
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							encryptionAnnotation: "sops",
+						},
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "secret with no encrypted values",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Secret",
+					"stringData": map[string]interface{}{
+						"password": "plaintext",
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "non-secret object",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, transformer.CanTransform(tt.obj))
+		})
+	}
+}
+
+func TestEncryptedSecretTransformer_Transform(t *testing.T) {
+	transformer := NewEncryptedSecretTransformer(&fakeKeyProvider{prefix: sopsEnvelopePrefix})
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"stringData": map[string]interface{}{
+				"username": "admin",
+				"password": sopsEnvelopePrefix + "secret123",
+			},
+		},
+	}
+
+	err := transformer.Transform(obj)
+	assert.NoError(t, err)
+
+	stringData, _, _ := unstructured.NestedMap(obj.Object, "stringData")
+	assert.Equal(t, "admin", stringData["username"])
+	assert.Equal(t, "secret123", stringData["password"])
+}
+
+func TestEncryptedSecretTransformer_Transform_NoProviderMatches(t *testing.T) {
+	transformer := NewEncryptedSecretTransformer()
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"stringData": map[string]interface{}{
+				"password": sopsEnvelopePrefix + "secret123",
+			},
+		},
+	}
+
+	err := transformer.Transform(obj)
+	assert.Error(t, err)
+}
+
+// TestEncryptedSecretTransformer_DiffEqualityAgainstObserved demonstrates that
+// a desired Secret storing SOPS ciphertext diffs clean against an observed
+// Secret the cluster already stores in plaintext base64, once the
+// EncryptedSecretTransformer runs ahead of the SecretTransformer.
+func TestEncryptedSecretTransformer_DiffEqualityAgainstObserved(t *testing.T) {
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name": "test-secret",
+			},
+			"stringData": map[string]interface{}{
+				"password": sopsEnvelopePrefix + "secret123",
+			},
+		},
+	}
+
+	decryptTransformer := NewEncryptedSecretTransformer(&fakeKeyProvider{prefix: sopsEnvelopePrefix})
+	assert.NoError(t, decryptTransformer.Transform(desired))
+
+	base64Transformer := &SecretTransformer{}
+	assert.NoError(t, base64Transformer.Transform(desired))
+
+	data, _, _ := unstructured.NestedMap(desired.Object, "data")
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("secret123")), data["password"])
+
+	observed := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name": "test-secret",
+			},
+			"data": map[string]interface{}{
+				"password": base64.StdEncoding.EncodeToString([]byte("secret123")),
+			},
+		},
+	}
+
+	differences, err := Compare(desired, observed)
+	require.NoError(t, err)
+	assert.Empty(t, differences)
+}
+
+func TestPrefixTransformer_Decrypt(t *testing.T) {
+	p := &PrefixTransformer{
+		Providers: []KeyProvider{
+			&fakeKeyProvider{prefix: "AGE["},
+			&fakeKeyProvider{prefix: sopsEnvelopePrefix},
+		},
+	}
+
+	plaintext, err := p.Decrypt(sopsEnvelopePrefix + "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", plaintext)
+
+	_, err = p.Decrypt("unrecognized-envelope")
+	assert.Error(t, err)
+}
+
+func TestPrefixTransformer_Decrypt_ProviderError(t *testing.T) {
+	p := &PrefixTransformer{
+		Providers: []KeyProvider{
+			&fakeKeyProvider{prefix: sopsEnvelopePrefix, decryptErr: fmt.Errorf("key not found")},
+		},
+	}
+
+	_, err := p.Decrypt(sopsEnvelopePrefix + "hello")
+	assert.Error(t, err)
+}
+
+// sealSOPSAESGCMEnvelope builds a SOPS-shaped AES256_GCM envelope for
+// plaintext, sealed with key and a random nonce, for use as test fixtures.
+func sealSOPSAESGCMEnvelope(t *testing.T, key, plaintext []byte) string {
+	t.Helper()
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	iv := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(iv)
+	require.NoError(t, err)
+
+	sealed := gcm.Seal(nil, iv, plaintext, nil)
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return fmt.Sprintf("%sdata:%s,iv:%s,tag:%s,type:str]",
+		sopsEnvelopePrefix,
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+	)
+}
+
+func TestAESGCMSecretKeyProvider_Decrypt(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	envelope := sealSOPSAESGCMEnvelope(t, key, []byte("secret123"))
+
+	provider := &AESGCMSecretKeyProvider{Key: key}
+	assert.True(t, provider.CanDecrypt(envelope))
+
+	plaintext, err := provider.Decrypt(envelope)
+	require.NoError(t, err)
+	assert.Equal(t, "secret123", plaintext)
+}
+
+func TestAESGCMSecretKeyProvider_Decrypt_WrongKeyFails(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	envelope := sealSOPSAESGCMEnvelope(t, key, []byte("secret123"))
+
+	wrongKey := make([]byte, 32)
+	_, err = rand.Read(wrongKey)
+	require.NoError(t, err)
+
+	_, err = (&AESGCMSecretKeyProvider{Key: wrongKey}).Decrypt(envelope)
+	assert.Error(t, err)
+}
+
+func TestAESGCMSecretKeyProvider_Decrypt_MalformedEnvelope(t *testing.T) {
+	key := make([]byte, 32)
+	provider := &AESGCMSecretKeyProvider{Key: key}
+
+	_, err := provider.Decrypt(sopsEnvelopePrefix + "data:only]")
+	assert.Error(t, err)
+}
+
+func TestEncryptedSecretTransformer_Transform_WithAESGCMProvider(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"stringData": map[string]interface{}{
+				"password": sealSOPSAESGCMEnvelope(t, key, []byte("secret123")),
+			},
+		},
+	}
+
+	transformer := NewEncryptedSecretTransformer(&AESGCMSecretKeyProvider{Key: key})
+	require.NoError(t, transformer.Transform(obj))
+
+	stringData, _, _ := unstructured.NestedMap(obj.Object, "stringData")
+	assert.Equal(t, "secret123", stringData["password"])
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TransformerRegistry holds a set of registered transformers. Callers that
+// reconcile against several target clusters can keep one TransformerRegistry
+// per cluster, so a transformer that only applies to one cluster (e.g. one
+// that strips a sidecar container that cluster's admission policy doesn't
+// allow) doesn't leak into comparisons against the others.
+type TransformerRegistry struct {
+	mu           sync.RWMutex
+	transformers []ObjectTransformer
+}
+
+// NewTransformerRegistry returns an empty TransformerRegistry.
+func NewTransformerRegistry() *TransformerRegistry {
+	return &TransformerRegistry{}
+}
+
+// Register adds a new transformer to the registry.
+func (r *TransformerRegistry) Register(t ObjectTransformer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transformers = append(r.transformers, t)
+}
+
+// TransformObjectToServerSideRepresentation applies every transformer in the
+// registry that can handle obj, in registration order.
+func (r *TransformerRegistry) TransformObjectToServerSideRepresentation(obj *unstructured.Unstructured) error {
+	r.mu.RLock()
+	transformers := append([]ObjectTransformer(nil), r.transformers...)
+	r.mu.RUnlock()
+
+	for _, transformer := range transformers {
+		if transformer.CanTransform(obj) {
+			if err := transformer.Transform(obj); err != nil {
+				return fmt.Errorf("failed to transform object: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultRegistry is the package-level registry backing RegisterTransformer
+// and TransformObjectToServerSideRepresentation, kept for callers that only
+// ever compare against a single cluster. MultiClusterCompare also falls back
+// to it for any cluster without a registry of its own.
+var defaultRegistry = NewTransformerRegistry()
+
+// RegisterTransformer adds a new transformer to the default registry
+func RegisterTransformer(t ObjectTransformer) {
+	defaultRegistry.Register(t)
+}
+
+// TransformObjectToServerSideRepresentation applies all applicable
+// transformers in the default registry to the object
+func TransformObjectToServerSideRepresentation(obj *unstructured.Unstructured) error {
+	return defaultRegistry.TransformObjectToServerSideRepresentation(obj)
+}
+
+// MultiClusterCompare compares a single desired manifest against observed
+// state in several clusters, applying each cluster's TransformerRegistry to
+// its own copy of desired before diffing, and returns the per-cluster diff
+// sets keyed the same as observed. A cluster without an entry in registries
+// falls back to the package's default registry.
+func MultiClusterCompare(
+	registries map[string]*TransformerRegistry,
+	desired *unstructured.Unstructured,
+	observed map[string]*unstructured.Unstructured,
+) (map[string][]Difference, error) {
+	results := make(map[string][]Difference, len(observed))
+
+	for cluster, observedObj := range observed {
+		registry := registries[cluster]
+		if registry == nil {
+			registry = defaultRegistry
+		}
+
+		clusterDesired := desired.DeepCopy()
+		if err := registry.TransformObjectToServerSideRepresentation(clusterDesired); err != nil {
+			return nil, fmt.Errorf("failed to transform desired object for cluster %q: %w", cluster, err)
+		}
+
+		differences, err := Compare(clusterDesired, observedObj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare cluster %q: %w", cluster, err)
+		}
+		results[cluster] = differences
+	}
+
+	return results, nil
+}
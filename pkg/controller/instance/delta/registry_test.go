@@ -0,0 +1,186 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// stripSidecarTransformer removes a named container from spec.containers,
+// modeling a cluster whose admission policy strips a sidecar the manifest
+// declares (e.g. a cluster that has opted out of an istio-proxy injection
+// every other cluster still carries).
+type stripSidecarTransformer struct {
+	containerName string
+}
+
+func (t *stripSidecarTransformer) CanTransform(obj *unstructured.Unstructured) bool {
+	return obj.GetKind() == "Deployment"
+}
+
+func (t *stripSidecarTransformer) Transform(obj *unstructured.Unstructured) error {
+	containers, found, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	if err != nil || !found {
+		return nil
+	}
+
+	filtered := containers[:0]
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if ok && container["name"] == t.containerName {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, filtered, "spec", "template", "spec", "containers")
+}
+
+func TestTransformerRegistry_RegisterAndTransform(t *testing.T) {
+	registry := NewTransformerRegistry()
+	registry.Register(&stripSidecarTransformer{containerName: "istio-proxy"})
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "app"},
+							map[string]interface{}{"name": "istio-proxy"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, registry.TransformObjectToServerSideRepresentation(obj))
+
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	assert.Len(t, containers, 1)
+	assert.Equal(t, "app", containers[0].(map[string]interface{})["name"])
+}
+
+func TestMultiClusterCompare_SameSecretDiffCleanAgainstDifferentObservedState(t *testing.T) {
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name": "test-secret",
+			},
+			"stringData": map[string]interface{}{
+				"password": "secret123",
+			},
+		},
+	}
+
+	clusterA := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name": "test-secret",
+			},
+			"data": map[string]interface{}{
+				"password": base64.StdEncoding.EncodeToString([]byte("secret123")),
+			},
+		},
+	}
+
+	clusterB := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "Opaque",
+			"metadata": map[string]interface{}{
+				"name":            "test-secret",
+				"uid":             "5678",
+				"resourceVersion": "42",
+			},
+			"data": map[string]interface{}{
+				"password": base64.StdEncoding.EncodeToString([]byte("secret123")),
+			},
+		},
+	}
+
+	results, err := MultiClusterCompare(nil, desired, map[string]*unstructured.Unstructured{
+		"cluster-a": clusterA,
+		"cluster-b": clusterB,
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, results["cluster-a"])
+	assert.Empty(t, results["cluster-b"])
+}
+
+func TestMultiClusterCompare_UsesPerClusterRegistry(t *testing.T) {
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name": "test-deployment",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "app"},
+							map[string]interface{}{"name": "istio-proxy"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	observedWithoutSidecar := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name": "test-deployment",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "app"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	registryWithSidecarStrip := NewTransformerRegistry()
+	registryWithSidecarStrip.Register(&stripSidecarTransformer{containerName: "istio-proxy"})
+
+	results, err := MultiClusterCompare(
+		map[string]*TransformerRegistry{"cluster-without-sidecar": registryWithSidecarStrip},
+		desired,
+		map[string]*unstructured.Unstructured{"cluster-without-sidecar": observedWithoutSidecar},
+	)
+	require.NoError(t, err)
+	assert.Empty(t, results["cluster-without-sidecar"])
+}
@@ -0,0 +1,359 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// TransformerConfig carries the shared dependencies transformers need to
+// talk to the cluster. It is passed to NewSchemaDefaultingTransformer;
+// Compare does not take a TransformerConfig and does not construct any
+// transformers itself, so a SchemaDefaultingTransformer only takes part in
+// Compare once it has been registered.
+type TransformerConfig struct {
+	// DiscoveryClient is used by SchemaDefaultingTransformer to fetch the
+	// cluster's OpenAPI v3 schema. May be nil, in which case schema-driven
+	// defaulting is skipped.
+	DiscoveryClient discovery.DiscoveryInterface
+	// SchemaCache caches fetched schemas across Transform calls. If nil,
+	// NewSchemaDefaultingTransformer creates a new SchemaCache.
+	SchemaCache *SchemaCache
+}
+
+// openAPISchema is the parsed subset of an OpenAPI v3 document we care
+// about: per-property default values and the set of read-only fields the
+// API server strips from anything a client submits.
+type openAPISchema struct {
+	Defaults  map[string]interface{} `json:"-"`
+	ReadOnly  map[string]bool        `json:"-"`
+	rawSchema map[string]interface{}
+}
+
+// SchemaCache caches a cluster's parsed OpenAPI v3 schemas keyed by GVK, so
+// repeated reconciles don't re-fetch schema the API server already gave us
+// once.
+type SchemaCache struct {
+	mu      sync.RWMutex
+	schemas map[schema.GroupVersionKind]*openAPISchema
+}
+
+// NewSchemaCache returns an empty SchemaCache.
+func NewSchemaCache() *SchemaCache {
+	return &SchemaCache{schemas: make(map[schema.GroupVersionKind]*openAPISchema)}
+}
+
+// Get returns the cached schema for gvk, if any.
+func (c *SchemaCache) Get(gvk schema.GroupVersionKind) (*openAPISchema, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.schemas[gvk]
+	return s, ok
+}
+
+// Set stores the schema for gvk.
+func (c *SchemaCache) Set(gvk schema.GroupVersionKind, s *openAPISchema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schemas[gvk] = s
+}
+
+// SchemaDefaultingTransformer applies the documented server-side defaults
+// from a resource's OpenAPI v3 schema and prunes fields the API server is
+// known to strip. It replaces the need for a hand-written transformer per
+// Kind: Service, Deployment, PodDisruptionBudget and HorizontalPodAutoscaler
+// all show false-positive diffs today purely because the server fills in
+// fields the schema already documents defaults for.
+//
+// Register this transformer after the hand-written ones (SecretTransformer,
+// ...) so resource-specific handling still wins where it exists;
+// schema-driven defaulting only fills in what's left.
+type SchemaDefaultingTransformer struct {
+	config TransformerConfig
+}
+
+// NewSchemaDefaultingTransformer builds a SchemaDefaultingTransformer backed
+// by cfg. If cfg.SchemaCache is nil, a new one is created.
+func NewSchemaDefaultingTransformer(cfg TransformerConfig) *SchemaDefaultingTransformer {
+	if cfg.SchemaCache == nil {
+		cfg.SchemaCache = NewSchemaCache()
+	}
+	return &SchemaDefaultingTransformer{config: cfg}
+}
+
+// CanTransform returns true as long as a discovery client is configured;
+// Transform itself falls back to a no-op when the schema can't be fetched.
+func (t *SchemaDefaultingTransformer) CanTransform(obj *unstructured.Unstructured) bool {
+	return t.config.DiscoveryClient != nil
+}
+
+// Transform prunes obj's fields the schema documents as read-only (and
+// therefore stripped by the API server), applies obj's documented OpenAPI v3
+// defaults for any field left unset, and normalizes quantity fields (e.g.
+// "cpu: 0.5" -> "cpu: 500m") to their canonical server-side string form. If
+// the schema is unavailable for obj's GVK, Transform leaves obj untouched
+// rather than failing the compare.
+func (t *SchemaDefaultingTransformer) Transform(obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+
+	s, err := t.schemaFor(gvk)
+	if err != nil || s == nil {
+		return nil
+	}
+
+	pruneReadOnlyFields(obj.Object, s.ReadOnly)
+	applySchemaDefaults(obj.Object, s.Defaults, "")
+	normalizeQuantities(obj.Object)
+	return nil
+}
+
+// schemaFor returns the cached, parsed OpenAPI v3 schema for gvk, fetching
+// and parsing it from the discovery client on a cache miss.
+func (t *SchemaDefaultingTransformer) schemaFor(gvk schema.GroupVersionKind) (*openAPISchema, error) {
+	if cached, ok := t.config.SchemaCache.Get(gvk); ok {
+		return cached, nil
+	}
+
+	parsed, err := fetchAndParseOpenAPIV3Schema(t.config.DiscoveryClient, gvk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OpenAPI v3 schema for %s: %w", gvk, err)
+	}
+
+	t.config.SchemaCache.Set(gvk, parsed)
+	return parsed, nil
+}
+
+// fetchAndParseOpenAPIV3Schema fetches the raw OpenAPI v3 document covering
+// gvk from the cluster's discovery endpoint and extracts the defaults and
+// read-only fields relevant to defaulting.
+func fetchAndParseOpenAPIV3Schema(client discovery.DiscoveryInterface, gvk schema.GroupVersionKind) (*openAPISchema, error) {
+	paths, err := client.OpenAPIV3().Paths()
+	if err != nil {
+		return nil, err
+	}
+
+	path, ok := paths[openAPIV3GroupVersionPath(gvk.GroupVersion())]
+	if !ok {
+		return nil, fmt.Errorf("no OpenAPI v3 path documents group/version %s", gvk.GroupVersion())
+	}
+
+	raw, err := path.Schema("application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI v3 document: %w", err)
+	}
+
+	return parseOpenAPISchemaForKind(doc, gvk)
+}
+
+// openAPIV3GroupVersionPath returns the discovery path key for a group
+// version, e.g. "api/v1" for core/v1 and "apis/apps/v1" otherwise.
+func openAPIV3GroupVersionPath(gv schema.GroupVersion) string {
+	if gv.Group == "" {
+		return "api/" + gv.Version
+	}
+	return "apis/" + gv.Group + "/" + gv.Version
+}
+
+// openAPIGroupSegment returns the group segment used in OpenAPI v3 schema
+// definition names, e.g. "core" for the empty core group and "apps" for
+// "apps", matching how the API server names built-in types such as
+// "io.k8s.api.core.v1.Service" and "io.k8s.api.apps.v1.Deployment".
+func openAPIGroupSegment(group string) string {
+	if group == "" {
+		return "core"
+	}
+	return group
+}
+
+// parseOpenAPISchemaForKind extracts the `default:` fields and read-only
+// fields documented for gvk.Kind from a parsed OpenAPI v3 document.
+func parseOpenAPISchemaForKind(doc map[string]interface{}, gvk schema.GroupVersionKind) (*openAPISchema, error) {
+	definitionName := fmt.Sprintf("io.k8s.api.%s.%s.%s", openAPIGroupSegment(gvk.Group), gvk.Version, gvk.Kind)
+	definitions, _ := doc["components"].(map[string]interface{})
+	schemas, _ := definitions["schemas"].(map[string]interface{})
+
+	def, ok := schemas[definitionName].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no schema definition named %q", definitionName)
+	}
+
+	result := &openAPISchema{
+		Defaults:  make(map[string]interface{}),
+		ReadOnly:  make(map[string]bool),
+		rawSchema: def,
+	}
+	collectDefaultsAndReadOnly(def, "", result.Defaults, result.ReadOnly)
+	return result, nil
+}
+
+// collectDefaultsAndReadOnly walks a schema's "properties" recursively,
+// recording each field's documented `default:` value and whether it is
+// `readOnly:` (and therefore stripped by the API server), keyed by
+// dot-separated field path.
+func collectDefaultsAndReadOnly(node map[string]interface{}, prefix string, defaults map[string]interface{}, readOnly map[string]bool) {
+	properties, _ := node["properties"].(map[string]interface{})
+	for field, rawProp := range properties {
+		prop, ok := rawProp.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := field
+		if prefix != "" {
+			path = prefix + "." + field
+		}
+		if d, ok := prop["default"]; ok {
+			defaults[path] = d
+		}
+		if ro, ok := prop["readOnly"].(bool); ok && ro {
+			readOnly[path] = true
+		}
+		collectDefaultsAndReadOnly(prop, path, defaults, readOnly)
+	}
+}
+
+// applySchemaDefaults sets any field in data that is unset but has a
+// documented default in defaults, keyed by dot-separated field path.
+func applySchemaDefaults(data map[string]interface{}, defaults map[string]interface{}, prefix string) {
+	for path, def := range defaults {
+		if prefix != "" && !pathHasPrefix(path, prefix) {
+			continue
+		}
+		setIfAbsent(data, splitPath(trimPrefix(path, prefix)), def)
+	}
+}
+
+// pruneReadOnlyFields removes every field documented readOnly in the schema
+// from data, mirroring how the API server strips them from anything a
+// client submits (e.g. status, metadata.uid), so a desired manifest that
+// accidentally carries one doesn't show up as drift.
+func pruneReadOnlyFields(data map[string]interface{}, readOnly map[string]bool) {
+	for path := range readOnly {
+		unstructured.RemoveNestedField(data, splitPath(path)...)
+	}
+}
+
+// normalizeQuantities rewrites known resource-quantity fields (cpu, memory)
+// under spec.template.spec.containers[*].resources, or under
+// spec.containers[*].resources when there is no pod template, to their
+// canonical server-side string form, e.g. "0.5" -> "500m" for cpu, via
+// resource.Quantity.
+func normalizeQuantities(data map[string]interface{}) {
+	path := []string{"spec", "template", "spec", "containers"}
+	containers, ok, _ := unstructured.NestedSlice(data, path...)
+	if !ok {
+		path = []string{"spec", "containers"}
+		containers, ok, _ = unstructured.NestedSlice(data, path...)
+	}
+	if !ok {
+		return
+	}
+
+	for i, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, resourceKind := range []string{"requests", "limits"} {
+			normalizeQuantityField(container, resourceKind, "cpu", normalizeCPUQuantity)
+			normalizeQuantityField(container, resourceKind, "memory", normalizeMemoryQuantity)
+		}
+		containers[i] = container
+	}
+
+	_ = unstructured.SetNestedSlice(data, containers, path...)
+}
+
+// normalizeQuantityField rewrites resources[resourceKind][field] using
+// normalize, leaving it untouched if absent or unparseable.
+func normalizeQuantityField(container map[string]interface{}, resourceKind, field string, normalize func(string) (string, error)) {
+	value, ok, _ := unstructured.NestedString(container, "resources", resourceKind, field)
+	if !ok {
+		return
+	}
+	normalized, err := normalize(value)
+	if err != nil {
+		return
+	}
+	_ = unstructured.SetNestedField(container, normalized, "resources", resourceKind, field)
+}
+
+// normalizeCPUQuantity round-trips a CPU quantity (e.g. "1", "0.5", "500m")
+// through resource.Quantity.String() to reach its canonical server-side
+// form. The API server stores quantities in that canonical form, which is not
+// always millicores: "0.5" becomes "500m", but "1" stays "1" rather than
+// "1000m", so this uses String() rather than MilliValue(), same as
+// normalizeMemoryQuantity.
+func normalizeCPUQuantity(cpu string) (string, error) {
+	q, err := resource.ParseQuantity(cpu)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse cpu quantity %q: %w", cpu, err)
+	}
+	return q.String(), nil
+}
+
+// normalizeMemoryQuantity round-trips a memory quantity (e.g. "1Gi") through
+// resource.Quantity.String() to reach its canonical server-side form.
+func normalizeMemoryQuantity(memory string) (string, error) {
+	q, err := resource.ParseQuantity(memory)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse memory quantity %q: %w", memory, err)
+	}
+	return q.String(), nil
+}
+
+func pathHasPrefix(path, prefix string) bool {
+	return len(path) >= len(prefix) && path[:len(prefix)] == prefix
+}
+
+func trimPrefix(path, prefix string) string {
+	if prefix == "" {
+		return path
+	}
+	return path[len(prefix)+1:]
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			parts = append(parts, path[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, path[start:])
+	return parts
+}
+
+// setIfAbsent sets fields[...path] = value only if no value is already
+// present there, so documented defaults never clobber a value the user (or
+// the cluster) already set.
+func setIfAbsent(data map[string]interface{}, path []string, value interface{}) {
+	if _, exists, _ := unstructured.NestedFieldNoCopy(data, path...); exists {
+		return
+	}
+	_ = unstructured.SetNestedField(data, value, path...)
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSchemaDefaultingTransformer_CanTransform(t *testing.T) {
+	withoutClient := NewSchemaDefaultingTransformer(TransformerConfig{DiscoveryClient: nil})
+	assert.False(t, withoutClient.CanTransform(&unstructured.Unstructured{}))
+}
+
+func TestParseOpenAPISchemaForKind_RealisticDocumentKeys(t *testing.T) {
+	// Shaped like a real OpenAPI v3 document: definitions are keyed
+	// "io.k8s.api.<group>.<version>.<Kind>", with "core" standing in for the
+	// empty core group.
+	doc := map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"io.k8s.api.core.v1.Service": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"properties": map[string]interface{}{
+								"sessionAffinity": map[string]interface{}{
+									"default": "None",
+								},
+							},
+						},
+					},
+				},
+				"io.k8s.api.apps.v1.Deployment": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"status": map[string]interface{}{
+							"readOnly": true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	service, err := parseOpenAPISchemaForKind(doc, schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Service"})
+	require.NoError(t, err)
+	assert.Equal(t, "None", service.Defaults["spec.sessionAffinity"])
+
+	deployment, err := parseOpenAPISchemaForKind(doc, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	require.NoError(t, err)
+	assert.True(t, deployment.ReadOnly["status"])
+
+	_, err = parseOpenAPISchemaForKind(doc, schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"})
+	assert.Error(t, err)
+}
+
+func TestCollectDefaultsAndReadOnly(t *testing.T) {
+	schemaDoc := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"sessionAffinity": map[string]interface{}{
+						"default": "None",
+					},
+					"clusterIP": map[string]interface{}{
+						"readOnly": true,
+					},
+				},
+			},
+		},
+	}
+
+	defaults := make(map[string]interface{})
+	readOnly := make(map[string]bool)
+	collectDefaultsAndReadOnly(schemaDoc, "", defaults, readOnly)
+
+	assert.Equal(t, "None", defaults["spec.sessionAffinity"])
+	assert.True(t, readOnly["spec.clusterIP"])
+}
+
+func TestApplySchemaDefaults(t *testing.T) {
+	data := map[string]interface{}{
+		"spec": map[string]interface{}{},
+	}
+	defaults := map[string]interface{}{
+		"spec.sessionAffinity": "None",
+	}
+
+	applySchemaDefaults(data, defaults, "")
+
+	sessionAffinity, _, _ := unstructured.NestedString(data, "spec", "sessionAffinity")
+	assert.Equal(t, "None", sessionAffinity)
+}
+
+func TestApplySchemaDefaults_DoesNotClobberExistingValue(t *testing.T) {
+	data := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"sessionAffinity": "ClientIP",
+		},
+	}
+	defaults := map[string]interface{}{
+		"spec.sessionAffinity": "None",
+	}
+
+	applySchemaDefaults(data, defaults, "")
+
+	sessionAffinity, _, _ := unstructured.NestedString(data, "spec", "sessionAffinity")
+	assert.Equal(t, "ClientIP", sessionAffinity)
+}
+
+func TestNormalizeCPUQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "whole core", input: "1", expected: "1"},
+		{name: "multiple cores", input: "2", expected: "2"},
+		{name: "already millicpu", input: "500m", expected: "500m"},
+		{name: "fractional core", input: "0.5", expected: "500m"},
+		{name: "fractional core above one", input: "1.5", expected: "1500m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeCPUQuantity(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNormalizeMemoryQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "gibibytes", input: "1Gi", expected: "1Gi"},
+		{name: "mebibytes", input: "512Mi", expected: "512Mi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeMemoryQuantity(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNormalizeQuantities(t *testing.T) {
+	data := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": "app",
+					"resources": map[string]interface{}{
+						"requests": map[string]interface{}{
+							"cpu":    "0.5",
+							"memory": "1Gi",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	normalizeQuantities(data)
+
+	containers, _, _ := unstructured.NestedSlice(data, "spec", "containers")
+	container := containers[0].(map[string]interface{})
+	requestCPU, _, _ := unstructured.NestedString(container, "resources", "requests", "cpu")
+	assert.Equal(t, "500m", requestCPU)
+	requestMemory, _, _ := unstructured.NestedString(container, "resources", "requests", "memory")
+	assert.Equal(t, "1Gi", requestMemory)
+}
+
+func TestPruneReadOnlyFields(t *testing.T) {
+	data := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"clusterIP":       "10.0.0.1",
+			"sessionAffinity": "None",
+		},
+		"status": map[string]interface{}{
+			"loadBalancer": map[string]interface{}{},
+		},
+	}
+	readOnly := map[string]bool{
+		"spec.clusterIP": true,
+		"status":         true,
+	}
+
+	pruneReadOnlyFields(data, readOnly)
+
+	_, exists, _ := unstructured.NestedFieldNoCopy(data, "spec", "clusterIP")
+	assert.False(t, exists)
+	_, exists, _ = unstructured.NestedFieldNoCopy(data, "status")
+	assert.False(t, exists)
+
+	sessionAffinity, _, _ := unstructured.NestedString(data, "spec", "sessionAffinity")
+	assert.Equal(t, "None", sessionAffinity)
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// TransformerOptions configures optional, higher-cost transformers that are
+// off by default because they make API server calls.
+type TransformerOptions struct {
+	// EnableDryRun turns on ServerSideDryRunTransformer.
+	EnableDryRun bool
+	// DryRunGVKs restricts ServerSideDryRunTransformer to the listed GVKs,
+	// since dry-run apply is an extra API call per object and most Kinds
+	// don't need it.
+	DryRunGVKs []schema.GroupVersionKind
+}
+
+// allows returns true if gvk is in o.DryRunGVKs.
+func (o TransformerOptions) allows(gvk schema.GroupVersionKind) bool {
+	for _, allowed := range o.DryRunGVKs {
+		if allowed == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerSideDryRunTransformer replaces the desired object with whatever the
+// API server would apply it as, via a server-side apply dry-run. This
+// delegates defaulting, admission-webhook mutation, and validation to the
+// cluster itself, eliminating whole classes of hand-written transformer
+// code at the cost of one extra API call per compared object.
+//
+// Results are cached for the lifetime of the transformer, keyed by a hash
+// of the input object, so comparing the same desired object against
+// multiple observed representations doesn't re-issue the dry-run apply.
+// Nothing clears or expires the cache; entries are not scoped to a single
+// reconcile pass.
+type ServerSideDryRunTransformer struct {
+	client    dynamic.Interface
+	namespace string
+	options   TransformerOptions
+
+	mu    sync.Mutex
+	cache map[string]*unstructured.Unstructured
+}
+
+// NewServerSideDryRunTransformer builds a ServerSideDryRunTransformer that
+// issues dry-run applies against client in namespace, gated by options.
+func NewServerSideDryRunTransformer(client dynamic.Interface, namespace string, options TransformerOptions) *ServerSideDryRunTransformer {
+	return &ServerSideDryRunTransformer{
+		client:    client,
+		namespace: namespace,
+		options:   options,
+		cache:     make(map[string]*unstructured.Unstructured),
+	}
+}
+
+// CanTransform returns true if dry-run is enabled and obj's GVK is in the
+// opt-in list.
+func (t *ServerSideDryRunTransformer) CanTransform(obj *unstructured.Unstructured) bool {
+	return t.options.EnableDryRun && t.options.allows(obj.GroupVersionKind())
+}
+
+// Transform submits obj as a server-side apply with dryRun=All and replaces
+// obj's contents with the server's response, so that fields only the server
+// or an admission webhook would add (e.g. spec.clusterIP, metadata.uid) no
+// longer show up as differences. If dry-run apply isn't supported for obj's
+// resource, Transform leaves obj untouched.
+func (t *ServerSideDryRunTransformer) Transform(obj *unstructured.Unstructured) error {
+	key, err := hashObject(obj)
+	if err != nil {
+		return fmt.Errorf("failed to hash object for dry-run cache: %w", err)
+	}
+
+	if cached, ok := t.cachedResult(key); ok {
+		obj.Object = cached.DeepCopy().Object
+		return nil
+	}
+
+	result, err := t.dryRunApply(obj)
+	if err != nil {
+		if apierrors.IsMethodNotSupported(err) || apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to dry-run apply object: %w", err)
+	}
+
+	t.cacheResult(key, result)
+	obj.Object = result.DeepCopy().Object
+	return nil
+}
+
+func (t *ServerSideDryRunTransformer) cachedResult(key string) (*unstructured.Unstructured, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result, ok := t.cache[key]
+	return result, ok
+}
+
+func (t *ServerSideDryRunTransformer) cacheResult(key string, result *unstructured.Unstructured) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cache[key] = result
+}
+
+// dryRunApply submits obj as a server-side apply with dryRun=All.
+func (t *ServerSideDryRunTransformer) dryRunApply(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	gvr, _ := apimeta.UnsafeGuessKindToResource(obj.GroupVersionKind())
+
+	var resource dynamic.ResourceInterface = t.client.Resource(gvr).Namespace(t.namespace)
+	if t.namespace == "" {
+		resource = t.client.Resource(gvr)
+	}
+
+	return resource.Apply(context.Background(), obj.GetName(), obj, metav1.ApplyOptions{
+		FieldManager: "kro-delta-dryrun",
+		DryRun:       []string{metav1.DryRunAll},
+	})
+}
+
+// hashObject returns a stable hash of obj's contents, used as the dry-run
+// result cache key.
+func hashObject(obj *unstructured.Unstructured) (string, error) {
+	raw, err := json.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf("%x", sum), nil
+}
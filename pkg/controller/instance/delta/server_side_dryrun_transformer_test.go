@@ -0,0 +1,151 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func serviceGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+}
+
+func serviceGVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Service"}
+}
+
+func newTestService(name, namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"port": int64(80)},
+				},
+			},
+		},
+	}
+}
+
+func TestServerSideDryRunTransformer_CanTransform(t *testing.T) {
+	scheme := runtime.NewScheme()
+	client := fake.NewSimpleDynamicClient(scheme)
+
+	enabled := NewServerSideDryRunTransformer(client, "default", TransformerOptions{
+		EnableDryRun: true,
+		DryRunGVKs:   []schema.GroupVersionKind{serviceGVK()},
+	})
+	assert.True(t, enabled.CanTransform(newTestService("svc", "default")))
+
+	disabled := NewServerSideDryRunTransformer(client, "default", TransformerOptions{EnableDryRun: false})
+	assert.False(t, disabled.CanTransform(newTestService("svc", "default")))
+
+	notOptedIn := NewServerSideDryRunTransformer(client, "default", TransformerOptions{EnableDryRun: true})
+	assert.False(t, notOptedIn.CanTransform(newTestService("svc", "default")))
+}
+
+// TestServerSideDryRunTransformer_Transform_ReplacesWithServerResponse shows
+// that Compare reports no differences when only server-added fields (e.g.
+// spec.clusterIP) diverge, because Transform swaps the desired object for
+// the server's dry-run response.
+func TestServerSideDryRunTransformer_Transform_ReplacesWithServerResponse(t *testing.T) {
+	scheme := runtime.NewScheme()
+	client := fake.NewSimpleDynamicClient(scheme)
+
+	serverResponse := newTestService("svc", "default")
+	serverResponse.Object["spec"].(map[string]interface{})["clusterIP"] = "10.0.0.1"
+	serverResponse.Object["metadata"].(map[string]interface{})["uid"] = "1234"
+
+	client.PrependReactor("patch", "services", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, serverResponse, nil
+	})
+
+	transformer := NewServerSideDryRunTransformer(client, "default", TransformerOptions{
+		EnableDryRun: true,
+		DryRunGVKs:   []schema.GroupVersionKind{serviceGVK()},
+	})
+
+	desired := newTestService("svc", "default")
+	require.NoError(t, transformer.Transform(desired))
+
+	clusterIP, _, _ := unstructured.NestedString(desired.Object, "spec", "clusterIP")
+	assert.Equal(t, "10.0.0.1", clusterIP)
+
+	uid, _, _ := unstructured.NestedString(desired.Object, "metadata", "uid")
+	assert.Equal(t, "1234", uid)
+
+	observed := newTestService("svc", "default")
+	observed.Object["spec"].(map[string]interface{})["clusterIP"] = "10.0.0.1"
+	observed.Object["metadata"].(map[string]interface{})["uid"] = "1234"
+
+	differences, err := Compare(desired, observed)
+	require.NoError(t, err)
+	assert.Empty(t, differences)
+}
+
+func TestServerSideDryRunTransformer_Transform_CachesByObjectHash(t *testing.T) {
+	scheme := runtime.NewScheme()
+	client := fake.NewSimpleDynamicClient(scheme)
+
+	serverResponse := newTestService("svc", "default")
+	serverResponse.Object["spec"].(map[string]interface{})["clusterIP"] = "10.0.0.1"
+
+	calls := 0
+	client.PrependReactor("patch", "services", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		calls++
+		return true, serverResponse, nil
+	})
+
+	transformer := NewServerSideDryRunTransformer(client, "default", TransformerOptions{
+		EnableDryRun: true,
+		DryRunGVKs:   []schema.GroupVersionKind{serviceGVK()},
+	})
+
+	require.NoError(t, transformer.Transform(newTestService("svc", "default")))
+	require.NoError(t, transformer.Transform(newTestService("svc", "default")))
+
+	assert.Equal(t, 1, calls, "expected the second Transform of an identical object to hit the cache")
+}
+
+func TestHashObject_StableAcrossEquivalentObjects(t *testing.T) {
+	a := newTestService("svc", "default")
+	b := newTestService("svc", "default")
+
+	hashA, err := hashObject(a)
+	require.NoError(t, err)
+	hashB, err := hashObject(b)
+	require.NoError(t, err)
+
+	assert.Equal(t, hashA, hashB)
+
+	b.Object["spec"].(map[string]interface{})["ports"] = []interface{}{
+		map[string]interface{}{"port": int64(8080)},
+	}
+	hashC, err := hashObject(b)
+	require.NoError(t, err)
+	assert.NotEqual(t, hashA, hashC)
+}
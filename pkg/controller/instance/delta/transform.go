@@ -28,26 +28,6 @@ type ObjectTransformer interface {
 	CanTransform(obj *unstructured.Unstructured) bool
 }
 
-// transformerRegistry holds all registered transformers
-var transformerRegistry []ObjectTransformer
-
-// RegisterTransformer adds a new transformer to the registry
-func RegisterTransformer(t ObjectTransformer) {
-	transformerRegistry = append(transformerRegistry, t)
-}
-
-// TransformObjectToServerSideRepresentation applies all applicable transformers to the object
-func TransformObjectToServerSideRepresentation(obj *unstructured.Unstructured) error {
-	for _, transformer := range transformerRegistry {
-		if transformer.CanTransform(obj) {
-			if err := transformer.Transform(obj); err != nil {
-				return fmt.Errorf("failed to transform object: %w", err)
-			}
-		}
-	}
-	return nil
-}
-
 // SecretTransformer handles transformation of Secret objects
 type SecretTransformer struct{}
 
@@ -57,10 +37,11 @@ func (t *SecretTransformer) CanTransform(obj *unstructured.Unstructured) bool {
 }
 
 // Transform modifies the Secret object to match server-side representation:
-// - Moves stringData to data with base64 encoding
-// - Merges any existing data with encoded stringData (stringData takes precedence)
+//   - Moves stringData to data with base64 encoding
+//   - Merges any existing data with encoded stringData (stringData takes precedence)
+//   - Unless a data entry already decodes to the same plaintext, in which case
+//     the existing base64 is kept rather than re-encoded
 func (t *SecretTransformer) Transform(obj *unstructured.Unstructured) error {
-	// Get stringData if it exists
 	stringData, exists, err := unstructured.NestedMap(obj.Object, "stringData")
 	if err != nil {
 		return fmt.Errorf("failed to get stringData: %w", err)
@@ -69,32 +50,80 @@ func (t *SecretTransformer) Transform(obj *unstructured.Unstructured) error {
 		return nil
 	}
 
-	// Get existing data or create new map
+	preferExistingBase64(obj, stringData)
+	if err := unstructured.SetNestedMap(obj.Object, stringData, "stringData"); err != nil {
+		return fmt.Errorf("failed to set stringData: %w", err)
+	}
+
+	if err := promoteStringMapToBase64(obj, "stringData", "data"); err != nil {
+		return err
+	}
+
+	// promoteStringMapToBase64 only removes stringData when it still has
+	// entries left to promote; remove it here too in case preferExistingBase64
+	// emptied it out first.
+	unstructured.RemoveNestedField(obj.Object, "stringData")
+	return nil
+}
+
+// preferExistingBase64 removes from stringData any key whose plaintext value
+// already matches what the corresponding data entry decodes to, so that
+// hand-written base64 the server has already normalized isn't needlessly
+// re-encoded.
+func preferExistingBase64(obj *unstructured.Unstructured, stringData map[string]interface{}) {
 	data, exists, err := unstructured.NestedMap(obj.Object, "data")
+	if err != nil || !exists {
+		return
+	}
+	for k, v := range stringData {
+		strVal, ok := v.(string)
+		if !ok {
+			continue
+		}
+		existingEncoded, ok := data[k].(string)
+		if !ok {
+			continue
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(existingEncoded); err == nil && string(decoded) == strVal {
+			delete(stringData, k)
+		}
+	}
+}
+
+// promoteStringMapToBase64 base64-encodes every value in obj's srcField,
+// merges the result into dstField (overwriting any existing entry with the
+// same key), and removes srcField. This is the shared base64-promotion logic
+// behind SecretTransformer's stringData -> data move.
+func promoteStringMapToBase64(obj *unstructured.Unstructured, srcField, dstField string) error {
+	src, exists, err := unstructured.NestedMap(obj.Object, srcField)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", srcField, err)
+	}
+	if !exists || len(src) == 0 {
+		return nil
+	}
+
+	dst, exists, err := unstructured.NestedMap(obj.Object, dstField)
 	if err != nil {
-		return fmt.Errorf("failed to get data: %w", err)
+		return fmt.Errorf("failed to get %s: %w", dstField, err)
 	}
 	if !exists {
-		data = make(map[string]interface{})
+		dst = make(map[string]interface{})
 	}
 
-	// Encode stringData values and add to data
-	for k, v := range stringData {
+	for k, v := range src {
 		strVal, ok := v.(string)
 		if !ok {
-			return fmt.Errorf("stringData value for key %q is not a string", k)
+			return fmt.Errorf("%s value for key %q is not a string", srcField, k)
 		}
-		encoded := base64.StdEncoding.EncodeToString([]byte(strVal))
-		data[k] = encoded
+		dst[k] = base64.StdEncoding.EncodeToString([]byte(strVal))
 	}
 
-	// Update data in the object
-	if err := unstructured.SetNestedMap(obj.Object, data, "data"); err != nil {
-		return fmt.Errorf("failed to set data: %w", err)
+	if err := unstructured.SetNestedMap(obj.Object, dst, dstField); err != nil {
+		return fmt.Errorf("failed to set %s: %w", dstField, err)
 	}
 
-	// Remove stringData
-	unstructured.RemoveNestedField(obj.Object, "stringData")
+	unstructured.RemoveNestedField(obj.Object, srcField)
 	return nil
 }
 
@@ -0,0 +1,141 @@
+// Copyright 2025 The Kube Resource Orchestrator Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delta
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSecretTransformer_Transform_PrefersExistingBase64(t *testing.T) {
+	transformer := &SecretTransformer{}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"data": map[string]interface{}{
+				"password": base64.StdEncoding.EncodeToString([]byte("secret123")),
+			},
+			"stringData": map[string]interface{}{
+				"password": "secret123",
+			},
+		},
+	}
+
+	err := transformer.Transform(obj)
+	assert.NoError(t, err)
+
+	data, _, _ := unstructured.NestedMap(obj.Object, "data")
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("secret123")), data["password"])
+
+	_, exists, _ := unstructured.NestedMap(obj.Object, "stringData")
+	assert.False(t, exists)
+}
+
+func TestPromoteStringMapToBase64(t *testing.T) {
+	invalidUTF8 := string([]byte{0xff, 0xfe, 0x00})
+
+	tests := []struct {
+		name        string
+		obj         *unstructured.Unstructured
+		srcField    string
+		dstField    string
+		expected    map[string]interface{}
+		expectError bool
+	}{
+		{
+			name: "merge precedence: src overrides matching dst key, leaves others",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"src": map[string]interface{}{
+						"key1": "value1",
+						"key2": "value2",
+					},
+					"dst": map[string]interface{}{
+						"key1": base64.StdEncoding.EncodeToString([]byte("existing1")),
+						"key3": base64.StdEncoding.EncodeToString([]byte("value3")),
+					},
+				},
+			},
+			srcField: "src",
+			dstField: "dst",
+			expected: map[string]interface{}{
+				"key1": base64.StdEncoding.EncodeToString([]byte("value1")),
+				"key2": base64.StdEncoding.EncodeToString([]byte("value2")),
+				"key3": base64.StdEncoding.EncodeToString([]byte("value3")),
+			},
+		},
+		{
+			name: "invalid UTF-8 values are encoded as raw bytes, not rejected",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"src": map[string]interface{}{
+						"key1": invalidUTF8,
+					},
+				},
+			},
+			srcField: "src",
+			dstField: "dst",
+			expected: map[string]interface{}{
+				"key1": base64.StdEncoding.EncodeToString([]byte(invalidUTF8)),
+			},
+		},
+		{
+			name: "empty src map is a no-op",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"src": map[string]interface{}{},
+				},
+			},
+			srcField: "src",
+			dstField: "dst",
+			expected: nil,
+		},
+		{
+			name: "non-string src value errors",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"src": map[string]interface{}{
+						"key1": int64(123),
+					},
+				},
+			},
+			srcField:    "src",
+			dstField:    "dst",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := promoteStringMapToBase64(tt.obj, tt.srcField, tt.dstField)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			dst, exists, _ := unstructured.NestedMap(tt.obj.Object, tt.dstField)
+			if tt.expected == nil {
+				assert.False(t, exists)
+				return
+			}
+			assert.Equal(t, tt.expected, dst)
+		})
+	}
+}